@@ -0,0 +1,113 @@
+package fileserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/z0rr0/ogo/middleware"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"hello.txt": &fstest.MapFile{Data: []byte("hello world")},
+	}
+}
+
+func TestFileServer_ServesFile(t *testing.T) {
+	h := FileServer(testFS())
+
+	req := httptest.NewRequest(http.MethodGet, "/hello.txt", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != "hello world" {
+		t.Errorf("unexpected body: %q", rec.Body.String())
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("expected ETag header to be set")
+	}
+}
+
+func TestFileServer_NotFound(t *testing.T) {
+	h := FileServer(testFS())
+
+	req := httptest.NewRequest(http.MethodGet, "/missing.txt", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestFileServer_RangeRequest(t *testing.T) {
+	h := FileServer(testFS())
+
+	req := httptest.NewRequest(http.MethodGet, "/hello.txt", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("expected 206, got %d", rec.Code)
+	}
+	if rec.Body.String() != "hello" {
+		t.Errorf("expected partial body %q, got %q", "hello", rec.Body.String())
+	}
+}
+
+func TestFileServer_RangeNotSatisfiable(t *testing.T) {
+	h := FileServer(testFS())
+
+	req := httptest.NewRequest(http.MethodGet, "/hello.txt", nil)
+	req.Header.Set("Range", "bytes=1000-2000")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected 416, got %d", rec.Code)
+	}
+}
+
+func TestFileServer_IfNoneMatch(t *testing.T) {
+	h := FileServer(testFS())
+
+	req := httptest.NewRequest(http.MethodGet, "/hello.txt", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	etag := rec.Header().Get("ETag")
+
+	req2 := httptest.NewRequest(http.MethodGet, "/hello.txt", nil)
+	req2.Header.Set("If-None-Match", etag)
+	rec2 := httptest.NewRecorder()
+	h.ServeHTTP(rec2, req2)
+
+	if rec2.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", rec2.Code)
+	}
+}
+
+func TestFileServer_RecordsServeStats(t *testing.T) {
+	h := FileServer(testFS())
+
+	stats := &middleware.ServeStats{}
+	ctx := context.WithValue(context.Background(), middleware.ServeStatsKey, stats)
+
+	req := httptest.NewRequest(http.MethodGet, "/hello.txt", nil).WithContext(ctx)
+	req.Header.Set("Range", "bytes=0-4")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if stats.BytesServed != 5 {
+		t.Errorf("expected 5 bytes served, got %d", stats.BytesServed)
+	}
+	if stats.Range != "bytes=0-4" {
+		t.Errorf("expected range to be recorded, got %q", stats.Range)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package fileserver
+
+import "io/fs"
+
+// inode is unavailable on non-unix platforms; the ETag falls back to (size, mtime) alone.
+func inode(_ fs.FileInfo) uint64 {
+	return 0
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package fileserver
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// inode returns the file's inode number, used as part of the ETag.
+func inode(info fs.FileInfo) uint64 {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	return uint64(stat.Ino)
+}
@@ -8,13 +8,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/z0rr0/ogo/fileserver"
 	"github.com/z0rr0/ogo/middleware"
 	"github.com/z0rr0/ogo/sandbox"
 )
@@ -23,22 +26,39 @@ const (
 	fatalDirCode = iota + 1
 	fatalSandboxCode
 	fatalServerCode
+	fatalProxyCode
+	fatalPrivilegeCode
+	fatalTLSCode
 )
 
 const serverTimeout = 3 * time.Second
 
 func main() {
 	var (
-		dir     = "."
-		addr    = ":8080"
-		timeout = 5 * time.Second
-		verbose bool
+		dir         = "."
+		addr        = ":8080"
+		timeout     = 5 * time.Second
+		proxyTO     = 10 * time.Second
+		verbose     bool
+		compress    bool
+		proxyMounts multiFlag
+		tlsCert     string
+		tlsKey      string
+		acme        string
+		acmeCache   = ".acme-cache"
 	)
 
 	flag.StringVar(&addr, "a", addr, "listen address")
 	flag.StringVar(&dir, "d", dir, "directory to show files")
 	flag.DurationVar(&timeout, "t", timeout, "shutdown timeout")
 	flag.BoolVar(&verbose, "v", verbose, "enable debug logging")
+	flag.BoolVar(&compress, "z", compress, "enable gzip/deflate/zstd response compression")
+	flag.Var(&proxyMounts, "p", "reverse proxy mount in PREFIX=TARGET form, may be repeated")
+	flag.DurationVar(&proxyTO, "pto", proxyTO, "reverse proxy dial/response timeout")
+	flag.StringVar(&tlsCert, "tls-cert", tlsCert, "TLS certificate file")
+	flag.StringVar(&tlsKey, "tls-key", tlsKey, "TLS key file")
+	flag.StringVar(&acme, "acme", acme, "comma-separated domains to obtain certificates for via ACME")
+	flag.StringVar(&acmeCache, "acme-cache", acmeCache, "directory to cache ACME certificates in")
 	flag.Parse()
 
 	setupLogger(os.Stdout, verbose)
@@ -49,30 +69,71 @@ func main() {
 		return // not required, only for clarity
 	}
 
+	mounts, err := parseProxyMounts(proxyMounts)
+	if err != nil {
+		fatal(fatalProxyCode, err, "invalid proxy mount")
+		return
+	}
+
+	tlsOpts := tlsOptions{certFile: tlsCert, keyFile: tlsKey, domains: parseACMEDomains(acme), cacheDir: acmeCache}
+	if tlsOpts.enabled() && os.Geteuid() == 0 {
+		fatal(fatalPrivilegeCode, errors.New("refusing to terminate TLS while running as root"), "privilege check failed")
+		return
+	}
+
 	// apply OpenBSD-specific security restrictions if available
-	if err = setupSecurity(absDir); err != nil {
+	if err = setupSecurity(absDir, mounts, tlsOpts); err != nil {
 		fatal(fatalSandboxCode, err, "failed to setup security restrictions")
 		return
 	}
 
-	fileServer := http.FileServerFS(os.DirFS(absDir))
+	var fileServer http.Handler = fileserver.FileServer(os.DirFS(absDir))
+	if compress {
+		fileServer = middleware.Compress(fileServer)
+	}
 	loggingServer := middleware.Logging(fileServer)
 	http.Handle("/", loggingServer)
 
+	for _, mount := range mounts {
+		proxyHandler := middleware.Logging(newReverseProxy(mount, proxyTO))
+		http.Handle(mount.prefix, proxyHandler)
+		slog.Info("mounted proxy", "prefix", mount.prefix, "target", mount.target.String())
+	}
+
 	server := &http.Server{
 		Addr:              addr,
 		Handler:           http.DefaultServeMux,
 		ReadHeaderTimeout: serverTimeout,
 	}
 
+	acmeMgr, err := configureTLS(server, tlsOpts)
+	if err != nil {
+		fatal(fatalTLSCode, err, "failed to configure TLS")
+		return
+	}
+
+	var redirect *http.Server
+	if tlsOpts.enabled() {
+		redirect = redirectServer(acmeMgr)
+	}
+
 	backgroundCtx := context.Background()
 	ctx, cancel := signal.NotifyContext(backgroundCtx, os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 	errCh := make(chan error, 1)
 
 	go func() {
-		slog.Info("starting", "address", addr, "directory", absDir)
-		listenErr := server.ListenAndServe()
+		slog.Info("starting", "address", addr, "directory", absDir, "tls", tlsOpts.enabled())
+
+		var listenErr error
+		if tlsOpts.enabled() {
+			var listener net.Listener
+			if listener, listenErr = net.Listen("tcp", addr); listenErr == nil {
+				listenErr = server.ServeTLS(listener, tlsOpts.certFile, tlsOpts.keyFile)
+			}
+		} else {
+			listenErr = server.ListenAndServe()
+		}
 
 		if listenErr != nil && !errors.Is(listenErr, http.ErrServerClosed) {
 			errCh <- listenErr
@@ -80,6 +141,15 @@ func main() {
 		}
 	}()
 
+	if redirect != nil {
+		go func() {
+			slog.Info("starting http redirect", "address", redirect.Addr)
+			if listenErr := redirect.ListenAndServe(); listenErr != nil && !errors.Is(listenErr, http.ErrServerClosed) {
+				slog.Error("http redirect listener failed", "error", listenErr)
+			}
+		}()
+	}
+
 	select {
 	case err = <-errCh:
 		fatal(fatalServerCode, err, "server failed")
@@ -94,6 +164,11 @@ func main() {
 	if err = server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("shutdown", "error", err)
 	}
+	if redirect != nil {
+		if err = redirect.Shutdown(shutdownCtx); err != nil {
+			slog.Error("redirect shutdown", "error", err)
+		}
+	}
 
 	slog.Info("stopped")
 }
@@ -118,23 +193,79 @@ func checkDirectory(dir string) (string, error) {
 }
 
 // setupSecurity applies OpenBSD-specific security restrictions using unveil and pledge.
-func setupSecurity(absDir string) error {
+// mounts is consulted to unveil any unix-socket proxy upstreams, and tlsOpts to unveil the
+// certificate/key files or ACME cache directory; both extend the pledge with the promises
+// required to use them.
+func setupSecurity(absDir string, mounts []proxyMount, tlsOpts tlsOptions) error {
 	err := sandbox.Unveil(absDir, "r")
 	if err != nil {
 		return fmt.Errorf("failed to unveil directory: %w", err)
 	}
 
+	for _, mount := range mounts {
+		if mount.target.Scheme != "unix" {
+			continue
+		}
+		if err = sandbox.Unveil(mount.target.Path, "rwc"); err != nil {
+			return fmt.Errorf("failed to unveil proxy socket %s: %w", mount.target.Path, err)
+		}
+	}
+
+	if tlsOpts.certMode() {
+		if err = sandbox.Unveil(tlsOpts.certFile, "r"); err != nil {
+			return fmt.Errorf("failed to unveil TLS certificate: %w", err)
+		}
+		if err = sandbox.Unveil(tlsOpts.keyFile, "r"); err != nil {
+			return fmt.Errorf("failed to unveil TLS key: %w", err)
+		}
+	}
+	if tlsOpts.acmeMode() {
+		if err = sandbox.Unveil(tlsOpts.cacheDir, "rwc"); err != nil {
+			return fmt.Errorf("failed to unveil ACME cache directory: %w", err)
+		}
+	}
+
 	if err = sandbox.UnveilBlock(); err != nil {
 		return fmt.Errorf("failed to block unveil: %w", err)
 	}
 
-	if err = sandbox.Pledge("stdio rpath inet"); err != nil {
+	if err = sandbox.Pledge(pledgePromises(mounts, tlsOpts)); err != nil {
 		return fmt.Errorf("failed to pledge: %w", err)
 	}
 
 	return nil
 }
 
+// pledgePromises builds the space-separated pledge promise string for the current
+// configuration, extending the base set with "dns" for outbound proxying/ACME and with
+// "wpath cpath" for TLS key access and ACME certificate caching.
+func pledgePromises(mounts []proxyMount, tlsOpts tlsOptions) string {
+	promises := []string{"stdio", "rpath", "inet"}
+	has := func(p string) bool {
+		for _, existing := range promises {
+			if existing == p {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(p string) {
+		if !has(p) {
+			promises = append(promises, p)
+		}
+	}
+
+	if len(mounts) > 0 || tlsOpts.acmeMode() {
+		add("dns")
+	}
+	if tlsOpts.enabled() {
+		add("wpath")
+		add("cpath")
+	}
+
+	return strings.Join(promises, " ")
+}
+
 // setupLogger configures the global logger with the specified output and verbosity.
 func setupLogger(w io.Writer, verbose bool) {
 	level := slog.LevelInfo
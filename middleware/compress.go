@@ -0,0 +1,386 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoding identifies a negotiated content-coding.
+type encoding string
+
+const (
+	encodingGzip    encoding = "gzip"
+	encodingDeflate encoding = "deflate"
+	encodingZstd    encoding = "zstd"
+)
+
+// sniffLen mirrors the number of bytes net/http itself uses to sniff content types.
+const sniffLen = 512
+
+// supportedEncodings lists the content-codings Compress is able to produce, in preference
+// order when the client's Accept-Encoding assigns equal weight to more than one. newEncoder
+// has a case for each of them; no other coding (br included) is produced.
+var supportedEncodings = []encoding{encodingZstd, encodingGzip, encodingDeflate}
+
+// compressedMIMEPrefixes are content types that are already compressed and not worth
+// re-encoding.
+var compressedMIMEPrefixes = []string{"image/", "video/", "audio/", "font/"}
+
+// compressedMIMETypes are exact already-compressed content types outside the prefixes above.
+var compressedMIMETypes = map[string]bool{
+	"application/zip":              true,
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/x-bzip2":          true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/pdf":              true,
+	"application/wasm":             true,
+}
+
+// compressConfig holds the tunables set by CompressOption values.
+type compressConfig struct {
+	level int
+}
+
+// CompressOption configures Compress.
+type CompressOption func(*compressConfig)
+
+// WithLevel sets the compression level passed to the underlying encoder. Its meaning is
+// encoder-specific; callers that don't care should leave the default in place.
+func WithLevel(level int) CompressOption {
+	return func(c *compressConfig) {
+		c.level = level
+	}
+}
+
+var (
+	gzipPool  = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	flatePool = sync.Pool{New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}}
+	zstdPool = sync.Pool{New: func() any {
+		w, _ := zstd.NewWriter(io.Discard)
+		return w
+	}}
+)
+
+// Compress wraps h so that responses are transparently gzip/deflate/zstd encoded according
+// to the request's Accept-Encoding header. It skips encoding for Range requests, for
+// responses whose content type is already compressed, and when the client doesn't ask for
+// a coding ogo can produce.
+func Compress(h http.Handler, opts ...CompressOption) http.Handler {
+	cfg := compressConfig{level: -1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: enc, level: cfg.level, isHead: r.Method == http.MethodHead}
+		defer func() {
+			if err := cw.Close(); err != nil {
+				slog.Error("compress", "error", err)
+			}
+		}()
+
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// flusher is implemented by all of the stdlib and klauspost/compress writers Compress pools.
+type flusher interface {
+	Flush() error
+}
+
+// compressWriter wraps http.ResponseWriter and lazily decides, on the first Write, whether
+// to encode the response body.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    encoding
+	level       int
+	statusCode  int
+	isHead      bool
+	wroteHeader bool
+	headerSent  bool
+	decided     bool
+	enc         io.WriteCloser
+}
+
+// WriteHeader records the status code; it is only forwarded once the encoding decision for
+// this response has been made, since that decision can still change the response headers.
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.statusCode = code
+}
+
+// Write sniffs the content type from the first chunk when the handler hasn't set one,
+// decides whether to compress, and forwards bytes through the chosen encoder.
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.decide(b)
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide sniffs the content type if needed and chooses whether to install an encoder,
+// then sends the (possibly adjusted) response header.
+func (cw *compressWriter) decide(sniff []byte) {
+	cw.decided = true
+	header := cw.ResponseWriter.Header()
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" && len(sniff) > 0 {
+		n := min(len(sniff), sniffLen)
+		contentType = http.DetectContentType(sniff[:n])
+		header.Set("Content-Type", contentType)
+	}
+
+	if isCompressedType(contentType) || header.Get("Content-Range") != "" || cw.isBodyless() {
+		cw.sendHeader()
+		return
+	}
+
+	header.Del("Content-Length")
+	header.Set("Vary", mergeVary(header.Get("Vary")))
+	header.Set("Content-Encoding", string(cw.encoding))
+
+	cw.enc = newEncoder(cw.encoding, cw.ResponseWriter, cw.level)
+	cw.sendHeader()
+}
+
+// isBodyless reports whether this response can't carry a body, per RFC 9110: HEAD requests,
+// 1xx informational, 204 No Content, and 304 Not Modified responses. Installing an encoder for
+// one of these would write the encoder's empty-stream trailer bytes as an invalid body.
+func (cw *compressWriter) isBodyless() bool {
+	if cw.isHead {
+		return true
+	}
+	code := cw.statusCode
+	if code == 0 {
+		code = http.StatusOK
+	}
+	return code == http.StatusNoContent || code == http.StatusNotModified || (code >= 100 && code < 200)
+}
+
+// sendHeader forwards the recorded status code to the underlying ResponseWriter exactly once.
+func (cw *compressWriter) sendHeader() {
+	if cw.headerSent {
+		return
+	}
+	cw.headerSent = true
+
+	code := cw.statusCode
+	if code == 0 {
+		code = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+// Flush implements http.Flusher, flushing any buffered encoder output before the underlying
+// ResponseWriter.
+func (cw *compressWriter) Flush() {
+	if f, ok := cw.enc.(flusher); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying ResponseWriter supports it.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("http.Hijacker not implemented by underlying ResponseWriter")
+}
+
+// Close finalizes and releases the encoder, if one was installed. It also guarantees the
+// response header is sent even when the handler never wrote a body.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		cw.decide(nil)
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	return cw.enc.Close()
+}
+
+// isCompressedType reports whether contentType is already compressed and not worth re-encoding.
+func isCompressedType(contentType string) bool {
+	mime, _, _ := strings.Cut(contentType, ";")
+	mime = strings.TrimSpace(mime)
+
+	if compressedMIMETypes[mime] {
+		return true
+	}
+	for _, prefix := range compressedMIMEPrefixes {
+		if strings.HasPrefix(mime, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeVary appends "Accept-Encoding" to an existing Vary header value, if not already present.
+func mergeVary(existing string) string {
+	if existing == "" {
+		return "Accept-Encoding"
+	}
+	for _, v := range strings.Split(existing, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), "Accept-Encoding") {
+			return existing
+		}
+	}
+	return existing + ", Accept-Encoding"
+}
+
+// negotiateEncoding picks the best supported encoding from an Accept-Encoding header,
+// honoring q-values and skipping codings ogo can't produce.
+func negotiateEncoding(header string) encoding {
+	if header == "" {
+		return ""
+	}
+
+	weights := make(map[encoding]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, q := parseEncodingToken(part)
+		if q > 0 {
+			weights[name] = q
+		} else if _, exists := weights[name]; !exists {
+			weights[name] = 0
+		}
+	}
+
+	var best encoding
+	bestQ := 0.0
+	for _, enc := range supportedEncodings {
+		q, ok := weights[enc]
+		if !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ {
+			bestQ = q
+			best = enc
+		}
+	}
+	return best
+}
+
+// parseEncodingToken parses one "name[;q=value]" token from an Accept-Encoding header.
+func parseEncodingToken(token string) (encoding, float64) {
+	name, params, _ := strings.Cut(token, ";")
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	q := 1.0
+	if qStr, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+		if parsed, err := strconv.ParseFloat(qStr, 64); err == nil {
+			q = parsed
+		}
+	}
+	return encoding(name), q
+}
+
+// newEncoder returns an encoder for enc writing into w: a pooled, reset one at the default
+// level, or a one-off encoder when a non-default level was configured.
+func newEncoder(enc encoding, w io.Writer, level int) io.WriteCloser {
+	switch enc {
+	case encodingGzip:
+		if level != -1 {
+			// a non-default level can't be applied to a pooled writer created with
+			// gzip.NewWriter, so build a one-off writer instead.
+			if gz, err := gzip.NewWriterLevel(w, level); err == nil {
+				return gz
+			}
+		}
+		gz := gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w)
+		return &pooledGzip{Writer: gz}
+	case encodingDeflate:
+		if level != -1 {
+			if fw, err := flate.NewWriter(w, level); err == nil {
+				return fw
+			}
+		}
+		fw := flatePool.Get().(*flate.Writer)
+		fw.Reset(w)
+		return &pooledFlate{Writer: fw}
+	case encodingZstd:
+		if level != -1 {
+			if zw, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level))); err == nil {
+				return zw
+			}
+		}
+		zw := zstdPool.Get().(*zstd.Encoder)
+		zw.Reset(w)
+		return &pooledZstd{Encoder: zw}
+	default:
+		return nopEncoder{w}
+	}
+}
+
+// pooledGzip returns its *gzip.Writer to gzipPool on Close.
+type pooledGzip struct{ *gzip.Writer }
+
+func (p *pooledGzip) Close() error {
+	err := p.Writer.Close()
+	p.Writer.Reset(io.Discard)
+	gzipPool.Put(p.Writer)
+	return err
+}
+
+// pooledFlate returns its *flate.Writer to flatePool on Close.
+type pooledFlate struct{ *flate.Writer }
+
+func (p *pooledFlate) Close() error {
+	err := p.Writer.Close()
+	p.Writer.Reset(io.Discard)
+	flatePool.Put(p.Writer)
+	return err
+}
+
+// pooledZstd returns its *zstd.Encoder to zstdPool on Close.
+type pooledZstd struct{ *zstd.Encoder }
+
+func (p *pooledZstd) Close() error {
+	err := p.Encoder.Close()
+	p.Encoder.Reset(io.Discard)
+	zstdPool.Put(p.Encoder)
+	return err
+}
+
+// nopEncoder is returned by newEncoder for an encoding it has no case for; Write/Close are
+// passthrough, so the body is written unencoded.
+type nopEncoder struct{ io.Writer }
+
+func (nopEncoder) Close() error { return nil }
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress_GzipNegotiated(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("hello world ", 100)))
+	})
+
+	wrapped := Compress(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected Content-Encoding gzip, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("expected Vary Accept-Encoding, got %q", got)
+	}
+
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response body is not valid gzip: %v", err)
+	}
+	body, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("failed to read gzip body: %v", err)
+	}
+	if !strings.Contains(string(body), "hello world") {
+		t.Error("decompressed body missing expected content")
+	}
+}
+
+func TestCompress_NoAcceptEncoding(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("plain"))
+	})
+
+	wrapped := Compress(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding, got %q", got)
+	}
+	if rec.Body.String() != "plain" {
+		t.Errorf("expected uncompressed body, got %q", rec.Body.String())
+	}
+}
+
+func TestCompress_SkipsRangeRequests(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-4/10")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	wrapped := Compress(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected Range request to bypass compression, got Content-Encoding %q", got)
+	}
+}
+
+func TestCompress_SkipsAlreadyCompressedType(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("fake-png-bytes"))
+	})
+
+	wrapped := Compress(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected image/png to skip compression, got Content-Encoding %q", got)
+	}
+}
+
+func TestCompress_SniffsContentType(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>hi</body></html>"))
+	})
+
+	wrapped := Compress(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if !strings.Contains(rec.Header().Get("Content-Type"), "html") {
+		t.Errorf("expected sniffed html content type, got %q", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestCompress_SkipsBodylessResponse(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		code   int
+	}{
+		{"NotModified", http.MethodGet, http.StatusNotModified},
+		{"NoContent", http.MethodGet, http.StatusNoContent},
+		{"Head", http.MethodHead, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.code)
+			})
+
+			wrapped := Compress(handler)
+
+			req := httptest.NewRequest(c.method, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+
+			wrapped.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("expected no Content-Encoding, got %q", got)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("expected empty body, got %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := []struct {
+		header string
+		want   encoding
+	}{
+		{"gzip", encodingGzip},
+		{"deflate", encodingDeflate},
+		{"zstd;q=1.0, gzip;q=0.5", encodingZstd},
+		{"identity", ""},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		if got := negotiateEncoding(c.header); got != c.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", c.header, got, c.want)
+		}
+	}
+}
+
+func TestIsCompressedType(t *testing.T) {
+	cases := map[string]bool{
+		"text/plain":       false,
+		"text/html":        false,
+		"image/png":        true,
+		"video/mp4":        true,
+		"application/zip":  true,
+		"application/json": false,
+	}
+
+	for ct, want := range cases {
+		if got := isCompressedType(ct); got != want {
+			t.Errorf("isCompressedType(%q) = %v, want %v", ct, got, want)
+		}
+	}
+}
+
+func TestMergeVary(t *testing.T) {
+	if got := mergeVary(""); got != "Accept-Encoding" {
+		t.Errorf("expected Accept-Encoding, got %q", got)
+	}
+	if got := mergeVary("Accept-Encoding"); got != "Accept-Encoding" {
+		t.Errorf("expected no duplication, got %q", got)
+	}
+	if got := mergeVary("Origin"); got != "Origin, Accept-Encoding" {
+		t.Errorf("expected appended value, got %q", got)
+	}
+}
+
+func TestCompressWriter_HijackNotSupported(t *testing.T) {
+	rec := httptest.NewRecorder()
+	cw := &compressWriter{ResponseWriter: rec}
+
+	_, _, err := cw.Hijack()
+	if err == nil {
+		t.Fatal("expected error when Hijacker not implemented")
+	}
+}
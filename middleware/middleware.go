@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"bufio"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
@@ -10,6 +11,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"regexp"
 	"strconv"
 	"sync"
 	"time"
@@ -18,8 +20,53 @@ import (
 const (
 	// requestIDSize is the size of the request ID in bytes.
 	requestIDSize = 8
+
+	// defaultRequestIDHeader is the header Logging reads an incoming request ID from and
+	// echoes the final one back on, unless overridden with WithRequestIDHeader.
+	defaultRequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLen bounds the length of a client-supplied request ID that Logging will trust.
+	maxRequestIDLen = 128
+)
+
+// hexRequestIDPattern and ulidRequestIDPattern are the request ID shapes Logging accepts
+// from a client; anything else is replaced with a freshly generated ID.
+var (
+	hexRequestIDPattern  = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	ulidRequestIDPattern = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{26}$`)
 )
 
+// requestIDKeyType is an unexported type for the request ID context key, to avoid collisions.
+type requestIDKeyType struct{}
+
+// RequestIDKey is the context key under which the per-request ID is stored.
+var RequestIDKey = requestIDKeyType{}
+
+// RequestIDFromContext returns the request ID stored in ctx by Logging, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(RequestIDKey).(string)
+	return id, ok
+}
+
+// ServeStats carries byte-range serving details a downstream handler (e.g. the fileserver
+// package) can fill in for Logging to include in its response log line.
+type ServeStats struct {
+	BytesServed int64
+	Range       string
+}
+
+// serveStatsKeyType is an unexported type for the ServeStats context key, to avoid collisions.
+type serveStatsKeyType struct{}
+
+// ServeStatsKey is the context key under which a *ServeStats is stored for the handler chain.
+var ServeStatsKey = serveStatsKeyType{}
+
+// ServeStatsFromContext returns the *ServeStats associated with ctx, if Logging installed one.
+func ServeStatsFromContext(ctx context.Context) (*ServeStats, bool) {
+	stats, ok := ctx.Value(ServeStatsKey).(*ServeStats)
+	return stats, ok
+}
+
 var (
 	requestIDPool = sync.Pool{
 		New: func() any {
@@ -63,24 +110,65 @@ func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return nil, nil, fmt.Errorf("http.Hijacker not implemented by underlying ResponseWriter")
 }
 
-// Logging wraps the handler and logs requests using the provided logger
-func Logging(h http.Handler) http.Handler {
+// LoggingOption configures Logging.
+type LoggingOption func(*loggingConfig)
+
+type loggingConfig struct {
+	requestIDHeader string
+}
+
+// WithRequestIDHeader overrides the header Logging reads an incoming request ID from and
+// echoes the final one back on. It defaults to X-Request-ID.
+func WithRequestIDHeader(name string) LoggingOption {
+	return func(c *loggingConfig) {
+		c.requestIDHeader = name
+	}
+}
+
+// Logging wraps the handler and logs requests using the provided logger. If the incoming
+// request already carries a well-formed request ID (hex or ULID, <= 128 chars) in the
+// configured header, it is reused instead of generating a new one; either way, the final ID
+// is echoed back to the client in the same header.
+func Logging(h http.Handler, opts ...LoggingOption) http.Handler {
+	cfg := loggingConfig{requestIDHeader: defaultRequestIDHeader}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID, err := getRequestID()
-		if err != nil {
-			slog.Error("requestID", "error", err)
-			requestID = strconv.Itoa(time.Now().Nanosecond()) // fallback
+		requestID := r.Header.Get(cfg.requestIDHeader)
+		if !isValidRequestID(requestID) {
+			var err error
+			requestID, err = getRequestID()
+			if err != nil {
+				slog.Error("requestID", "error", err)
+				requestID = strconv.Itoa(time.Now().Nanosecond()) // fallback
+			}
 		}
+		w.Header().Set(cfg.requestIDHeader, requestID)
+
 		start := time.Now()
 
 		logger := slog.Default().With("id", requestID, "method", r.Method, "path", r.URL.Path)
 		logger.Info("request", "remote", r.RemoteAddr)
 
+		stats := &ServeStats{}
+		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx = context.WithValue(ctx, ServeStatsKey, stats)
+		r = r.WithContext(ctx)
+
 		wrapped := &responseWriter{ResponseWriter: w}
 		h.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start).Round(time.Millisecond)
-		logger.Info("response", "duration", duration, "status", wrapped.statusCode)
+		args := []any{"duration", duration, "status", wrapped.statusCode}
+		if stats.BytesServed > 0 {
+			args = append(args, "bytes", stats.BytesServed)
+		}
+		if stats.Range != "" {
+			args = append(args, "range", stats.Range)
+		}
+		logger.Info("response", args...)
 	})
 }
 
@@ -99,3 +187,12 @@ func getRequestID() (string, error) {
 
 	return hex.EncodeToString(b), nil
 }
+
+// isValidRequestID reports whether id is safe to trust from an incoming request: non-empty,
+// no longer than maxRequestIDLen, and either hex or a ULID.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	return hexRequestIDPattern.MatchString(id) || ulidRequestIDPattern.MatchString(id)
+}
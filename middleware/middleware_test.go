@@ -3,6 +3,7 @@ package middleware
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"log/slog"
 	"net"
 	"net/http"
@@ -240,6 +241,48 @@ func TestLogging_ImplicitOKStatus(t *testing.T) {
 	}
 }
 
+func TestRequestIDFromContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), RequestIDKey, "abc123")
+
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		t.Fatal("expected request ID to be found in context")
+	}
+	if id != "abc123" {
+		t.Errorf("expected %q, got %q", "abc123", id)
+	}
+}
+
+func TestRequestIDFromContext_Missing(t *testing.T) {
+	_, ok := RequestIDFromContext(context.Background())
+	if ok {
+		t.Error("expected no request ID in an empty context")
+	}
+}
+
+func TestLogging_StoresRequestIDInContext(t *testing.T) {
+	var gotID string
+	var gotOK bool
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, gotOK = RequestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrapped := Logging(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+
+	if !gotOK {
+		t.Fatal("expected request ID to be present in handler's context")
+	}
+	if gotID == "" {
+		t.Error("expected non-empty request ID")
+	}
+}
+
 func TestGetRequestID(t *testing.T) {
 	id, err := getRequestID()
 	if err != nil {
@@ -392,3 +435,106 @@ func TestLogging_DifferentRequestsHaveDifferentIDs(t *testing.T) {
 		t.Errorf("different requests should have different IDs, both got: %s", id1)
 	}
 }
+
+func TestLogging_ReusesIncomingRequestID(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	wrapped := Logging(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "deadbeef")
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "deadbeef" {
+		t.Errorf("expected incoming request ID to be reused, got %q", got)
+	}
+}
+
+func TestLogging_RejectsInvalidIncomingRequestID(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	wrapped := Logging(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "not valid! id")
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got == "not valid! id" {
+		t.Error("expected malformed incoming request ID to be replaced")
+	}
+}
+
+func TestLogging_RejectsOverlongIncomingRequestID(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	wrapped := Logging(handler)
+
+	overlong := strings.Repeat("a", maxRequestIDLen+1)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", overlong)
+	rec := httptest.NewRecorder()
+
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got == overlong {
+		t.Error("expected overlong incoming request ID to be replaced")
+	}
+}
+
+func TestLogging_EchoesResponseHeader(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	wrapped := Logging(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+
+	if rec.Header().Get("X-Request-ID") == "" {
+		t.Error("expected X-Request-ID response header to be set")
+	}
+}
+
+func TestLogging_WithRequestIDHeader(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	wrapped := Logging(handler, WithRequestIDHeader("X-Trace-ID"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Trace-ID", "abc123def456")
+	rec := httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Trace-ID"); got != "abc123def456" {
+		t.Errorf("expected custom header to be reused and echoed, got %q", got)
+	}
+	if rec.Header().Get("X-Request-ID") != "" {
+		t.Error("expected default header to be unused when a custom one is configured")
+	}
+}
+
+func TestIsValidRequestID(t *testing.T) {
+	cases := map[string]bool{
+		"deadbeef":                   true,
+		"DEADBEEF":                   true,
+		"01ARZ3NDEKTSV4RRFFQ69G5FAV": true,
+		"":                           false,
+		"not valid! id":              false,
+		strings.Repeat("a", 129):     false,
+	}
+
+	for id, want := range cases {
+		if got := isValidRequestID(id); got != want {
+			t.Errorf("isValidRequestID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/z0rr0/ogo/middleware"
+)
+
+// proxyMount is a single PREFIX=TARGET reverse-proxy mount point.
+type proxyMount struct {
+	prefix string
+	target *url.URL
+}
+
+// multiFlag accumulates repeated occurrences of a string flag, such as -p.
+type multiFlag []string
+
+// String implements flag.Value.
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+// Set implements flag.Value and appends value to the accumulated list.
+func (m *multiFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
+// parseProxyMounts parses PREFIX=TARGET specs (e.g. "/api=http://127.0.0.1:9000" or
+// "/api=unix:///run/ogo/upstream.sock") into proxyMount values.
+func parseProxyMounts(specs []string) ([]proxyMount, error) {
+	mounts := make([]proxyMount, 0, len(specs))
+
+	for _, spec := range specs {
+		prefix, target, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid proxy mount %q, expected PREFIX=TARGET", spec)
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("proxy mount prefix %q must start with /", prefix)
+		}
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy target %q: %w", target, err)
+		}
+
+		mounts = append(mounts, proxyMount{prefix: prefix, target: u})
+	}
+
+	return mounts, nil
+}
+
+// newReverseProxy builds a handler that proxies requests under mount.prefix to mount.target,
+// stripping the prefix and streaming responses (including chunked and SSE) without buffering.
+func newReverseProxy(mount proxyMount, timeout time.Duration) http.Handler {
+	upstream := mount.target
+	transport := &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: timeout}).DialContext,
+		ResponseHeaderTimeout: timeout,
+	}
+
+	if upstream.Scheme == "unix" {
+		socketPath := upstream.Path
+		dialer := &net.Dialer{Timeout: timeout}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		// the Host/Scheme below are placeholders: the unix dialer ignores them, but
+		// ReverseProxy still needs a well-formed URL to build the outgoing request.
+		upstream = &url.URL{Scheme: "http", Host: "unix-socket"}
+	}
+
+	return &httputil.ReverseProxy{
+		Transport:     transport,
+		FlushInterval: -1, // stream without buffering, required for chunked and SSE responses
+		Director: func(r *http.Request) {
+			originalHost := r.Host
+
+			r.URL.Scheme = upstream.Scheme
+			r.URL.Host = upstream.Host
+			r.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, mount.prefix), "/")
+
+			// X-Forwarded-For is appended by httputil.ReverseProxy itself.
+			r.Header.Set("X-Forwarded-Host", originalHost)
+			r.Header.Set("X-Forwarded-Proto", forwardedProto(r))
+
+			if id, ok := middleware.RequestIDFromContext(r.Context()); ok {
+				r.Header.Set("X-Request-ID", id)
+			}
+		},
+	}
+}
+
+// forwardedProto reports the scheme the original request arrived on.
+func forwardedProto(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ogo/middleware"
+)
+
+func TestParseProxyMounts(t *testing.T) {
+	mounts, err := parseProxyMounts([]string{"/api=http://127.0.0.1:9000", "/ws/=unix:///run/ogo.sock"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %d", len(mounts))
+	}
+
+	if mounts[0].prefix != "/api/" {
+		t.Errorf("expected normalized prefix /api/, got %q", mounts[0].prefix)
+	}
+	if mounts[0].target.Host != "127.0.0.1:9000" {
+		t.Errorf("unexpected target host: %q", mounts[0].target.Host)
+	}
+
+	if mounts[1].target.Scheme != "unix" {
+		t.Errorf("expected unix scheme, got %q", mounts[1].target.Scheme)
+	}
+	if mounts[1].target.Path != "/run/ogo.sock" {
+		t.Errorf("unexpected unix socket path: %q", mounts[1].target.Path)
+	}
+}
+
+func TestParseProxyMounts_InvalidSpec(t *testing.T) {
+	if _, err := parseProxyMounts([]string{"no-equals-sign"}); err == nil {
+		t.Error("expected error for spec without '='")
+	}
+}
+
+func TestParseProxyMounts_PrefixMustBeAbsolute(t *testing.T) {
+	if _, err := parseProxyMounts([]string{"api=http://127.0.0.1:9000"}); err == nil {
+		t.Error("expected error for prefix not starting with /")
+	}
+}
+
+func TestNewReverseProxy_StripsPrefixAndForwardsHeaders(t *testing.T) {
+	var (
+		gotPath         string
+		gotHost         string
+		gotProto        string
+		gotRequestID    string
+		gotForwardedFor string
+	)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHost = r.Header.Get("X-Forwarded-Host")
+		gotProto = r.Header.Get("X-Forwarded-Proto")
+		gotRequestID = r.Header.Get("X-Request-ID")
+		gotForwardedFor = r.Header.Get("X-Forwarded-For")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatalf("failed to parse upstream URL: %v", err)
+	}
+	mount := proxyMount{prefix: "/api/", target: target}
+
+	proxy := newReverseProxy(mount, time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/widgets", nil)
+	req.Host = "client.example"
+	req.RemoteAddr = "10.0.0.1:1234"
+	ctx := context.WithValue(req.Context(), middleware.RequestIDKey, "req-42")
+	req = req.WithContext(ctx)
+
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 from upstream, got %d", rec.Code)
+	}
+	if gotPath != "/widgets" {
+		t.Errorf("expected stripped path /widgets, got %q", gotPath)
+	}
+	if gotHost != "client.example" {
+		t.Errorf("expected X-Forwarded-Host client.example, got %q", gotHost)
+	}
+	if gotProto != "http" {
+		t.Errorf("expected X-Forwarded-Proto http, got %q", gotProto)
+	}
+	if gotRequestID != "req-42" {
+		t.Errorf("expected X-Request-ID req-42, got %q", gotRequestID)
+	}
+	if gotForwardedFor != "10.0.0.1" {
+		t.Errorf("expected X-Forwarded-For 10.0.0.1, got %q", gotForwardedFor)
+	}
+}
+
+func TestMultiFlag(t *testing.T) {
+	var m multiFlag
+
+	if err := m.Set("/api=http://localhost:9000"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Set("/ws=http://localhost:9001"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(m))
+	}
+	if m.String() != "/api=http://localhost:9000,/ws=http://localhost:9001" {
+		t.Errorf("unexpected String() output: %q", m.String())
+	}
+}
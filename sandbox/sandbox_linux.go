@@ -0,0 +1,229 @@
+//go:build linux
+
+// Package sandbox provides sandboxing functions for Linux using Landlock LSM and seccomp-bpf.
+package sandbox
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Linux syscall numbers for Landlock, not yet exposed by golang.org/x/sys/unix.
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+)
+
+// Landlock ruleset/rule flags and access rights, mirroring linux/landlock.h.
+const (
+	landlockCreateRulesetVersion = 1 << 0
+
+	landlockRuleTypePathBeneath = 1
+
+	landlockAccessFSExecute    = 1 << 0
+	landlockAccessFSWriteFile  = 1 << 1
+	landlockAccessFSReadFile   = 1 << 2
+	landlockAccessFSReadDir    = 1 << 3
+	landlockAccessFSRemoveDir  = 1 << 4
+	landlockAccessFSRemoveFile = 1 << 5
+	landlockAccessFSMakeChar   = 1 << 6
+	landlockAccessFSMakeDir    = 1 << 7
+	landlockAccessFSMakeReg    = 1 << 8
+	landlockAccessFSMakeSock   = 1 << 9
+	landlockAccessFSMakeFifo   = 1 << 10
+	landlockAccessFSMakeBlock  = 1 << 11
+	landlockAccessFSMakeSym    = 1 << 12
+
+	landlockAccessFSAll = landlockAccessFSExecute | landlockAccessFSWriteFile | landlockAccessFSReadFile |
+		landlockAccessFSReadDir | landlockAccessFSRemoveDir | landlockAccessFSRemoveFile |
+		landlockAccessFSMakeChar | landlockAccessFSMakeDir | landlockAccessFSMakeReg |
+		landlockAccessFSMakeSock | landlockAccessFSMakeFifo | landlockAccessFSMakeBlock |
+		landlockAccessFSMakeSym
+)
+
+// landlockRulesetAttr mirrors struct landlock_ruleset_attr.
+type landlockRulesetAttr struct {
+	handledAccessFS uint64
+}
+
+// landlockPathBeneathAttr mirrors struct landlock_path_beneath_attr.
+type landlockPathBeneathAttr struct {
+	allowedAccessFS uint64
+	parentFD        int32
+}
+
+// rule is one accumulated Unveil() call, applied to the ruleset in UnveilBlock().
+type rule struct {
+	path   string
+	access uint64
+}
+
+var (
+	mu           sync.Mutex
+	rules        []rule
+	landlockable = landlockSupported()
+	seccompable  = seccompSupported()
+)
+
+// Unveil accumulates a Landlock filesystem rule for path with the given permissions.
+// The perms string can contain r (read), w (write), x (execute), and c (create).
+// Rules only take effect once UnveilBlock enforces the accumulated ruleset.
+func Unveil(path, perms string) error {
+	slog.Debug("unveil", "path", path, "perms", perms)
+
+	if !landlockable {
+		slog.Warn("landlock unsupported, unveil is a no-op", "path", path)
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	rules = append(rules, rule{path: path, access: permsToAccess(perms)})
+	return nil
+}
+
+// UnveilBlock builds a Landlock ruleset from the accumulated Unveil calls, restricts
+// the current thread's privileges, and enforces the ruleset on the process.
+// After this call, no further filesystem access beyond the unveiled rules is possible.
+func UnveilBlock() error {
+	slog.Debug("unveil block")
+
+	if !landlockable {
+		slog.Warn("landlock unsupported, unveil block is a no-op")
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	attr := landlockRulesetAttr{handledAccessFS: landlockAccessFSAll}
+	rulesetFD, _, errno := unix.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("landlock_create_ruleset: %w", errno)
+	}
+	defer unix.Close(int(rulesetFD))
+
+	for _, r := range rules {
+		if err := addPathRule(int(rulesetFD), r); err != nil {
+			return fmt.Errorf("landlock_add_rule %s: %w", r.path, err)
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("prctl PR_SET_NO_NEW_PRIVS: %w", err)
+	}
+
+	if _, _, errno = unix.Syscall(sysLandlockRestrictSelf, rulesetFD, 0, 0); errno != 0 {
+		return fmt.Errorf("landlock_restrict_self: %w", errno)
+	}
+
+	return nil
+}
+
+// Pledge restricts the system calls available to the process using a seccomp-bpf filter.
+// The promises string contains space-separated promises from the OpenBSD pledge vocabulary:
+// stdio, rpath, wpath, inet, dns, unix.
+func Pledge(promises string) error {
+	slog.Debug("pledge", "promises", promises)
+
+	if !seccompable {
+		slog.Warn("seccomp unsupported, pledge is a no-op", "promises", promises)
+		return nil
+	}
+
+	filter, err := seccompFilter(promises)
+	if err != nil {
+		return fmt.Errorf("build seccomp filter: %w", err)
+	}
+
+	if err = unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("prctl PR_SET_NO_NEW_PRIVS: %w", err)
+	}
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if err = unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("prctl PR_SET_SECCOMP: %w", err)
+	}
+
+	return nil
+}
+
+// addPathRule opens path as O_PATH and adds a LANDLOCK_RULE_PATH_BENEATH rule to rulesetFD.
+func addPathRule(rulesetFD int, r rule) error {
+	fd, err := unix.Open(r.path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", r.path, err)
+	}
+	defer unix.Close(fd)
+
+	attr := landlockPathBeneathAttr{allowedAccessFS: r.access, parentFD: int32(fd)}
+	_, _, errno := unix.Syscall6(sysLandlockAddRule, uintptr(rulesetFD), landlockRuleTypePathBeneath,
+		uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// permsToAccess maps the pledge-style perms string to a Landlock access-rights bitmask.
+func permsToAccess(perms string) uint64 {
+	var access uint64
+	for _, p := range perms {
+		switch p {
+		case 'r':
+			access |= landlockAccessFSReadFile | landlockAccessFSReadDir
+		case 'w':
+			access |= landlockAccessFSWriteFile | landlockAccessFSMakeReg | landlockAccessFSMakeDir |
+				landlockAccessFSMakeSym | landlockAccessFSMakeChar | landlockAccessFSMakeBlock |
+				landlockAccessFSMakeFifo | landlockAccessFSMakeSock | landlockAccessFSRemoveDir |
+				landlockAccessFSRemoveFile
+		case 'x':
+			access |= landlockAccessFSExecute
+		case 'c':
+			access |= landlockAccessFSMakeReg | landlockAccessFSMakeDir
+		}
+	}
+	return access
+}
+
+// landlockSupported reports whether the running kernel implements Landlock ABI >= 1.
+func landlockSupported() bool {
+	ret, _, errno := unix.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		slog.Warn("landlock unavailable, falling back to no-op unveil", "error", errno)
+		return false
+	}
+	return int(ret) >= 1
+}
+
+// seccompSupported reports whether the running kernel supports seccomp-bpf, by querying the
+// calling thread's current seccomp mode. A kernel built without CONFIG_SECCOMP, or one too old
+// to know about PR_GET_SECCOMP, fails this with ENOSYS/EINVAL.
+//
+// seccompFilter's syscall tables and architecture check are hand-built for x86_64 only, so
+// any other GOARCH is treated the same as an unsupported kernel: installing that filter on,
+// say, arm64 would make the BPF program's arch check fail on the very first syscall and kill
+// the process outright, rather than degrade gracefully.
+func seccompSupported() bool {
+	if runtime.GOARCH != "amd64" {
+		slog.Warn("seccomp filter only supports amd64, falling back to no-op pledge", "goarch", runtime.GOARCH)
+		return false
+	}
+
+	_, _, errno := unix.Syscall6(unix.SYS_PRCTL, unix.PR_GET_SECCOMP, 0, 0, 0, 0, 0)
+	if errno != 0 {
+		slog.Warn("seccomp unavailable, falling back to no-op pledge", "error", errno)
+		return false
+	}
+	return true
+}
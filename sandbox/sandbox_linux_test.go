@@ -0,0 +1,177 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// helperEnv selects the subprocess action performed by TestMain when re-exec'd.
+const helperEnv = "OGO_SANDBOX_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperEnv) {
+	case "open-outside":
+		runOpenOutsideHelper()
+	case "socket":
+		runSocketHelper()
+	}
+	os.Exit(m.Run())
+}
+
+// runOpenOutsideHelper unveils only os.TempDir() for read, blocks further unveils, then
+// tries to open a file outside that directory. It must fail with EACCES.
+func runOpenOutsideHelper() {
+	allowed := os.TempDir()
+	if err := Unveil(allowed, "r"); err != nil {
+		os.Exit(10)
+	}
+	if err := UnveilBlock(); err != nil {
+		os.Exit(11)
+	}
+
+	_, err := os.Open("/etc/hostname")
+	if errors.Is(err, unix.EACCES) || errors.Is(err, os.ErrPermission) {
+		os.Exit(0)
+	}
+	os.Exit(12)
+}
+
+// runSocketHelper pledges a promise set without "inet" and then tries to open a socket.
+// It must fail with EPERM once the seccomp filter is installed.
+func runSocketHelper() {
+	if err := Pledge("stdio rpath"); err != nil {
+		os.Exit(20)
+	}
+
+	_, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM, 0)
+	if errors.Is(err, unix.EPERM) {
+		os.Exit(0)
+	}
+	os.Exit(21)
+}
+
+// runHelper re-execs the test binary with helperEnv set and returns its exit code.
+func runHelper(t *testing.T, action string) int {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestMain")
+	cmd.Env = append(os.Environ(), helperEnv+"="+action)
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if err == nil {
+		return 0
+	}
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	t.Fatalf("failed to run helper process: %v", err)
+	return -1
+}
+
+func TestUnveilBlock_OpenOutsideUnveiledPathFails(t *testing.T) {
+	if !landlockable {
+		t.Skip("landlock unsupported by the running kernel")
+	}
+	if code := runHelper(t, "open-outside"); code != 0 {
+		t.Fatalf("expected helper to observe EACCES, exit code was %d", code)
+	}
+}
+
+func TestPledge_BlockedSyscallFailsWithEPERM(t *testing.T) {
+	if !seccompable {
+		t.Skip("seccomp unsupported by the running kernel")
+	}
+	if code := runHelper(t, "socket"); code != 0 {
+		t.Fatalf("expected helper to observe EPERM, exit code was %d", code)
+	}
+}
+
+func TestPledge_SeccompUnsupportedIsNoOp(t *testing.T) {
+	if seccompable {
+		t.Skip("seccomp is supported by the running kernel, can't exercise the fallback")
+	}
+	if err := Pledge("stdio bogus"); err != nil {
+		t.Errorf("expected Pledge to no-op when seccomp is unsupported, got %v", err)
+	}
+}
+
+func TestSeccompSupported_NonAMD64IsUnsupported(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		if seccompSupported() {
+			t.Errorf("expected seccompSupported to be false on GOARCH %q", runtime.GOARCH)
+		}
+	}
+}
+
+func TestPermsToAccess(t *testing.T) {
+	cases := []struct {
+		perms string
+		want  uint64
+	}{
+		{"r", landlockAccessFSReadFile | landlockAccessFSReadDir},
+		{"x", landlockAccessFSExecute},
+		{"", 0},
+	}
+
+	for _, c := range cases {
+		if got := permsToAccess(c.perms); got != c.want {
+			t.Errorf("permsToAccess(%q) = %#x, want %#x", c.perms, got, c.want)
+		}
+	}
+}
+
+func TestSeccompFilter_UnknownPromise(t *testing.T) {
+	if _, err := seccompFilter("stdio bogus"); err == nil {
+		t.Error("expected error for unknown promise")
+	}
+}
+
+func TestSeccompFilter_KnownPromises(t *testing.T) {
+	filter, err := seccompFilter("stdio rpath inet")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filter) == 0 {
+		t.Error("expected a non-empty BPF program")
+	}
+}
+
+func TestSeccompFilter_RejectsTooManySyscalls(t *testing.T) {
+	const promise = "overflow-test"
+	nums := make([]uint32, 256)
+	for i := range nums {
+		nums[i] = uint32(i)
+	}
+	promiseSyscalls[promise] = nums
+	defer delete(promiseSyscalls, promise)
+
+	if _, err := seccompFilter(promise); err == nil {
+		t.Error("expected error when allowed syscalls exceed a uint8 jump offset")
+	}
+}
+
+// TestPledge_AllMainPromiseCombinations mirrors every promise string main.pledgePromises can
+// build (base, +dns for proxy/ACME mode, +wpath/cpath for TLS/ACME mode, and all combined),
+// not just the individual promises in isolation.
+func TestPledge_AllMainPromiseCombinations(t *testing.T) {
+	combinations := []string{
+		"stdio rpath inet",
+		"stdio rpath inet dns",
+		"stdio rpath inet wpath cpath",
+		"stdio rpath inet dns wpath cpath",
+	}
+
+	for _, promises := range combinations {
+		if _, err := seccompFilter(promises); err != nil {
+			t.Errorf("seccompFilter(%q) failed: %v", promises, err)
+		}
+	}
+}
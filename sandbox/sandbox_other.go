@@ -1,4 +1,4 @@
-//go:build !openbsd
+//go:build !openbsd && !linux
 
 // Package sandbox provides platform-specific security restrictions using OpenBSD pledge/unveil.
 package sandbox
@@ -0,0 +1,122 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Classic BPF opcodes and seccomp-bpf return actions, not exposed by golang.org/x/sys/unix.
+const (
+	bpfLD  = 0x00
+	bpfW   = 0x00
+	bpfABS = 0x20
+	bpfJMP = 0x05
+	bpfJEQ = 0x10
+	bpfK   = 0x00
+	bpfRET = 0x06
+
+	seccompRetKillProcess uint32 = 0x80000000
+	seccompRetErrnoBase   uint32 = 0x00050000
+	seccompRetAllow       uint32 = 0x7fff0000
+
+	auditArchX8664 uint32 = 0xc000003e
+
+	seccompDataArchOffset = 4
+	seccompDataNROffset   = 0
+)
+
+// promiseSyscalls maps an OpenBSD pledge promise to the x86_64 syscall numbers it allows.
+// Numbers are taken from the Linux x86_64 syscall table; other architectures are not yet supported.
+var promiseSyscalls = map[string][]uint32{
+	"stdio": {
+		0, 1, 2, 3, 5, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 20, 21, 23, 25, 34, 35, // rw/mmap/sig/etc
+		39, 60, 61, 63, 72, 89, 96, 97, 102, 158, 186, 218, 228, 231, 273, 302, 318, // misc stdio
+	},
+	"rpath": {
+		2, 4, 5, 6, 19, 78, 79, 80, 89, 137, 217, 262, 263, // open/stat/getdents/readlink
+	},
+	"wpath": {
+		2, 8, 76, 77, 83, 85, 86, 87, 90, 92, 133, 263, // open/truncate/mkdir/creat/link/unlink/chmod/chown
+	},
+	"cpath": {
+		82, 83, 84, 86, 87, 88, 133, 258, 259, 263, 264, 265, 266, 316, // mkdir/rename/unlink/mknod + *at variants
+	},
+	"inet": {
+		41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, // socket/connect/bind/.../shutdown
+	},
+	"dns": {
+		41, 42, 44, 45, 46, 47, 54, // socket/connect/send/recv/setsockopt for resolver lookups
+	},
+	"unix": {
+		41, 42, 43, 44, 45, 46, 49, 50, // AF_UNIX socket/connect/accept/bind/listen
+	},
+}
+
+// seccompFilter builds a seccomp-bpf program that allows only the syscalls required by the
+// space-separated pledge promises; any other syscall fails with EPERM, and a syscall made
+// under a different architecture kills the process.
+func seccompFilter(promises string) ([]unix.SockFilter, error) {
+	allowed := make(map[uint32]bool)
+	for _, p := range strings.Fields(promises) {
+		nums, ok := promiseSyscalls[p]
+		if !ok {
+			return nil, fmt.Errorf("unknown promise %q", p)
+		}
+		for _, n := range nums {
+			allowed[n] = true
+		}
+	}
+
+	nums := make([]uint32, 0, len(allowed))
+	for n := range allowed {
+		nums = append(nums, n)
+	}
+
+	// jt below is packed into a uint8, so the forward jump distance to the final RET ALLOW
+	// instruction must stay representable; bail out instead of silently wrapping and
+	// producing a corrupted BPF program.
+	if len(nums) >= 256 {
+		return nil, fmt.Errorf("too many allowed syscalls (%d) for a uint8 BPF jump offset", len(nums))
+	}
+
+	// Program layout:
+	//   0: load arch
+	//   1: jump to 3 if it matches what this whitelist was built for, else fall to 2
+	//   2: kill process (wrong architecture)
+	//   3: load syscall number
+	//   4..4+n-1: one jump-if-equal-then-allow per whitelisted syscall, else fall to the next check
+	//   4+n: default return EPERM (no whitelisted syscall matched)
+	//   4+n+1: allow
+	filter := []unix.SockFilter{
+		bpfStmt(bpfLD|bpfW|bpfABS, seccompDataArchOffset),
+		bpfJump(bpfJMP|bpfJEQ|bpfK, auditArchX8664, 1, 0),
+		bpfStmt(bpfRET, seccompRetKillProcess),
+		bpfStmt(bpfLD|bpfW|bpfABS, seccompDataNROffset),
+	}
+
+	for i, n := range nums {
+		jt := uint8(len(nums) - i) // distance forward to the final RET ALLOW instruction
+		filter = append(filter, bpfJump(bpfJMP|bpfJEQ|bpfK, n, jt, 0))
+	}
+
+	filter = append(filter,
+		bpfStmt(bpfRET, seccompRetErrnoBase|uint32(unix.EPERM)),
+		bpfStmt(bpfRET, seccompRetAllow),
+	)
+
+	return filter, nil
+}
+
+// bpfStmt builds a non-jumping BPF instruction.
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+// bpfJump builds a BPF jump instruction.
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
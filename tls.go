@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+)
+
+// tlsOptions holds the flags that control TLS termination, either from a static
+// certificate/key pair or via ACME (Let's Encrypt).
+type tlsOptions struct {
+	certFile string
+	keyFile  string
+	domains  []string
+	cacheDir string
+}
+
+// certMode reports whether a static certificate/key pair was supplied.
+func (o tlsOptions) certMode() bool {
+	return o.certFile != "" && o.keyFile != ""
+}
+
+// acmeMode reports whether certificates should be obtained automatically via ACME.
+func (o tlsOptions) acmeMode() bool {
+	return len(o.domains) > 0
+}
+
+// enabled reports whether TLS termination was requested in either mode.
+func (o tlsOptions) enabled() bool {
+	return o.certMode() || o.acmeMode()
+}
+
+// parseACMEDomains splits a comma-separated -acme flag value into trimmed domain names.
+func parseACMEDomains(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+
+	domains := strings.Split(raw, ",")
+	for i, d := range domains {
+		domains[i] = strings.TrimSpace(d)
+	}
+	return domains
+}
+
+// configureTLS sets up server.TLSConfig and enables HTTP/2. In ACME mode it installs an
+// autocert.Manager that obtains and caches certificates on demand and returns it so the
+// caller can route HTTP-01 challenge requests to it via redirectServer; it returns nil in
+// certificate-file mode, where no challenge handling is needed.
+func configureTLS(server *http.Server, opts tlsOptions) (*autocert.Manager, error) {
+	if !opts.enabled() {
+		return nil, nil
+	}
+
+	var mgr *autocert.Manager
+	var tlsConfig *tls.Config
+
+	if opts.acmeMode() {
+		mgr = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.domains...),
+			Cache:      autocert.DirCache(opts.cacheDir),
+		}
+		// mgr.TLSConfig builds on GetCertificate and also advertises the "acme-tls/1"
+		// ALPN protocol, required for TLS-ALPN-01 challenge validation to complete.
+		tlsConfig = mgr.TLSConfig()
+	} else {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.MinVersion = tls.VersionTLS12
+
+	server.TLSConfig = tlsConfig
+
+	if err := http2.ConfigureServer(server, &http2.Server{}); err != nil {
+		return nil, fmt.Errorf("failed to configure http2: %w", err)
+	}
+
+	return mgr, nil
+}
+
+// redirectServer returns a server that redirects all plain HTTP requests to their https
+// equivalent, meant to be run alongside a TLS listener on :80. When mgr is non-nil, ACME
+// HTTP-01 challenge requests under /.well-known/acme-challenge/ are answered by mgr instead
+// of being redirected, since the challenge must complete over plain HTTP.
+func redirectServer(mgr *autocert.Manager) *http.Server {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := "https://" + r.Host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+
+	var handler http.Handler = redirect
+	if mgr != nil {
+		handler = mgr.HTTPHandler(redirect)
+	}
+
+	return &http.Server{
+		Addr:              ":80",
+		Handler:           handler,
+		ReadHeaderTimeout: serverTimeout,
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestParseACMEDomains(t *testing.T) {
+	domains := parseACMEDomains("example.com, www.example.com")
+	if len(domains) != 2 {
+		t.Fatalf("expected 2 domains, got %d", len(domains))
+	}
+	if domains[0] != "example.com" || domains[1] != "www.example.com" {
+		t.Errorf("unexpected domains: %v", domains)
+	}
+}
+
+func TestParseACMEDomains_Empty(t *testing.T) {
+	if domains := parseACMEDomains(""); domains != nil {
+		t.Errorf("expected nil for empty input, got %v", domains)
+	}
+}
+
+func TestTLSOptions_Modes(t *testing.T) {
+	none := tlsOptions{}
+	if none.enabled() {
+		t.Error("expected empty tlsOptions to be disabled")
+	}
+
+	cert := tlsOptions{certFile: "cert.pem", keyFile: "key.pem"}
+	if !cert.certMode() || !cert.enabled() || cert.acmeMode() {
+		t.Error("expected cert/key pair to select cert mode only")
+	}
+
+	acme := tlsOptions{domains: []string{"example.com"}}
+	if !acme.acmeMode() || !acme.enabled() || acme.certMode() {
+		t.Error("expected domains to select acme mode only")
+	}
+}
+
+func TestConfigureTLS_ACMEModeAdvertisesALPNChallenge(t *testing.T) {
+	opts := tlsOptions{domains: []string{"example.com"}, cacheDir: t.TempDir()}
+	server := &http.Server{}
+
+	mgr, err := configureTLS(server, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mgr == nil {
+		t.Fatal("expected a non-nil autocert.Manager in ACME mode")
+	}
+
+	found := false
+	for _, proto := range server.TLSConfig.NextProtos {
+		if proto == acme.ALPNProto {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected NextProtos to include %q for TLS-ALPN-01, got %v", acme.ALPNProto, server.TLSConfig.NextProtos)
+	}
+}
+
+func TestConfigureTLS_CertModeReturnsNilManager(t *testing.T) {
+	opts := tlsOptions{certFile: "cert.pem", keyFile: "key.pem"}
+	server := &http.Server{}
+
+	mgr, err := configureTLS(server, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mgr != nil {
+		t.Error("expected a nil autocert.Manager in certificate-file mode")
+	}
+}
+
+func TestRedirectServer_RoutesACMEChallengeToManager(t *testing.T) {
+	mgr := &autocert.Manager{Cache: autocert.DirCache(t.TempDir())}
+	server := redirectServer(mgr)
+
+	challengeReq := httptest.NewRequest(http.MethodGet, "/.well-known/acme-challenge/some-token", nil)
+	rec := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, challengeReq)
+
+	if rec.Code == http.StatusMovedPermanently {
+		t.Error("expected ACME challenge request to be handled by the manager, not redirected")
+	}
+
+	plainReq := httptest.NewRequest(http.MethodGet, "/some/path", nil)
+	rec = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, plainReq)
+
+	if rec.Code != http.StatusMovedPermanently {
+		t.Errorf("expected non-challenge request to be redirected, got %d", rec.Code)
+	}
+}
+
+func TestPledgePromises(t *testing.T) {
+	base := pledgePromises(nil, tlsOptions{})
+	if base != "stdio rpath inet" {
+		t.Errorf("unexpected base promises: %q", base)
+	}
+
+	withMounts := pledgePromises([]proxyMount{{prefix: "/api/"}}, tlsOptions{})
+	if withMounts != "stdio rpath inet dns" {
+		t.Errorf("unexpected promises with mounts: %q", withMounts)
+	}
+
+	withTLS := pledgePromises(nil, tlsOptions{certFile: "cert.pem", keyFile: "key.pem"})
+	if withTLS != "stdio rpath inet wpath cpath" {
+		t.Errorf("unexpected promises with TLS: %q", withTLS)
+	}
+
+	withACME := pledgePromises(nil, tlsOptions{domains: []string{"example.com"}})
+	if withACME != "stdio rpath inet dns wpath cpath" {
+		t.Errorf("unexpected promises with ACME: %q", withACME)
+	}
+}